@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/pquerna/otp/totp"
+)
+
+// CredentialSource supplies the Proxmox session ticket to use for the next
+// authenticated API call, refreshing it transparently as needed. This
+// mirrors the shape of oauth2.TokenSource, which solves the same problem for
+// OAuth2 access tokens: callers always ask the source for the current
+// credential instead of holding on to one themselves, so a ticket rotated in
+// the background is picked up immediately.
+type CredentialSource interface {
+	// Ticket returns the session to authenticate the next request with.
+	// It returns a nil session (and no error) if no ticket has been
+	// issued yet, e.g. while the initial login is still in flight.
+	Ticket(ctx context.Context) (*proxmox.Session, error)
+}
+
+// apiTokenCredentialSource is used for Credentials.TokenID logins. API
+// tokens are presented via an Authorization header by proxmox.WithAPIToken
+// rather than a session ticket, and they never expire on their own, so
+// there's nothing to track or refresh here.
+type apiTokenCredentialSource struct{}
+
+func (apiTokenCredentialSource) Ticket(context.Context) (*proxmox.Session, error) {
+	return nil, nil
+}
+
+// ticketCredentialSource backs realm logins that authenticate via a session
+// ticket, whether by password or by an OTP-derived TFA challenge. It caches
+// the most recently issued ticket so API calls share it, and
+// startSessionTicketRefresher periodically swaps in a freshly refreshed one
+// before Proxmox's session lifetime runs out.
+type ticketCredentialSource struct {
+	client      *proxmox.Client
+	credentials proxmox.Credentials
+	otpSecret   string
+
+	session atomic.Pointer[proxmox.Session]
+}
+
+func newTicketCredentialSource(client *proxmox.Client, credentials proxmox.Credentials, otpSecret string) *ticketCredentialSource {
+	return &ticketCredentialSource{
+		client:      client,
+		credentials: credentials,
+		otpSecret:   otpSecret,
+	}
+}
+
+func (s *ticketCredentialSource) Ticket(context.Context) (*proxmox.Session, error) {
+	return s.session.Load(), nil
+}
+
+// Refresh logs in (or re-authenticates) and atomically swaps in the new
+// session for subsequent Ticket calls. Once a ticket has already been
+// issued, it is presented in place of the real password: Proxmox accepts a
+// still-valid ticket this way as proof of identity and issues a fresh one
+// with a renewed expiry, without re-running TFA. If that renewal is
+// rejected, e.g. because the cached ticket has actually expired, it falls
+// back to a clean login with the real password (and OTP, if required) so
+// the source can recover instead of being wedged on an unrenewable ticket.
+func (s *ticketCredentialSource) Refresh(ctx context.Context) error {
+	if current := s.session.Load(); current != nil {
+		credentials := s.credentials
+		credentials.Password = current.Ticket
+
+		if session, err := s.login(ctx, credentials); err == nil {
+			s.session.Store(session)
+			return nil
+		}
+	}
+
+	session, err := s.login(ctx, s.credentials)
+	if err != nil {
+		return err
+	}
+
+	s.session.Store(session)
+	return nil
+}
+
+// login exchanges credentials for a session ticket, completing the TFA
+// challenge if the realm requires one.
+func (s *ticketCredentialSource) login(ctx context.Context, credentials proxmox.Credentials) (*proxmox.Session, error) {
+	session, err := s.client.Ticket(ctx, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain proxmox session ticket: %w", err)
+	}
+
+	if session.NeedTFA {
+		return s.completeTFAChallenge(ctx, session)
+	}
+
+	return session, nil
+}
+
+// completeTFAChallenge answers the partial ticket returned above with a TOTP
+// code derived from otpSecret, as Proxmox's two-step TFA login expects: the
+// first login step returns a ticket with NeedTFA set that isn't valid for
+// API calls on its own, and must be posted back as the password alongside
+// the OTP response to obtain a usable ticket.
+func (s *ticketCredentialSource) completeTFAChallenge(ctx context.Context, partial *proxmox.Session) (*proxmox.Session, error) {
+	if s.otpSecret == "" {
+		return nil, fmt.Errorf("proxmox realm requires a TFA challenge but no otpsecret is configured")
+	}
+
+	code, err := totp.GenerateCode(s.otpSecret, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate otp code: %w", err)
+	}
+
+	credentials := s.credentials
+	credentials.Password = partial.Ticket
+	credentials.OTP = code
+
+	session, err := s.client.Ticket(ctx, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete proxmox tfa challenge: %w", err)
+	}
+
+	return session, nil
+}
+
+// ticketTransport injects the CredentialSource's current session ticket into
+// every outgoing request, and its CSRF prevention token into every request
+// other than a GET; nothing is injected while the source has no ticket.
+// Because it asks the source fresh on each round trip rather than capturing a ticket at
+// construction time, a ticket rotated by startSessionTicketRefresher in the
+// background takes effect immediately without recreating the
+// proxmox.Client.
+type ticketTransport struct {
+	base    http.RoundTripper
+	credSrc CredentialSource
+}
+
+func (t *ticketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session, err := t.credSrc.Ticket(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain proxmox session ticket: %w", err)
+	}
+
+	if session != nil {
+		req = req.Clone(req.Context())
+		req.AddCookie(&http.Cookie{Name: "PVEAuthCookie", Value: session.Ticket})
+		if req.Method != http.MethodGet {
+			req.Header.Set("CSRFPreventionToken", session.CSRFPreventionToken)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
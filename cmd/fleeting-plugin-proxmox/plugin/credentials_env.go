@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+const dotEnvFilename = ".env"
+
+// Environment variables PROXMOX_* credentials are read from. Names follow
+// proxmox-api-go's convention so deployments that already export these for
+// other Proxmox tooling work here unchanged.
+const (
+	envVarURL       = "PROXMOX_URL"
+	envVarUsername  = "PROXMOX_USER"
+	envVarPassword  = "PROXMOX_PASSWORD"
+	envVarTokenID   = "PROXMOX_TOKEN_ID"
+	envVarRealm     = "PROXMOX_REALM"
+	envVarOtpSecret = "PROXMOX_OTP_SECRET"
+)
+
+// explicitEnvCredentialsSet reports whether a PROXMOX_* variable is present
+// in the real process environment, deliberately not consulting a .env file.
+// getProxmoxCredentials uses this to detect a genuine conflict with an
+// explicitly configured credentials_file; a .env file merely picked up as a
+// fallback shouldn't count as one.
+func explicitEnvCredentialsSet() bool {
+	for _, name := range []string{envVarURL, envVarUsername, envVarPassword, envVarTokenID, envVarRealm, envVarOtpSecret} {
+		if _, ok := os.LookupEnv(name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadCredentialsFromEnv reads PROXMOX_* environment variables, falling back
+// to a .env file in the working directory for any that aren't already set.
+// Values already present in the real environment always win, so a .env file
+// can't silently shadow something the deployment set on purpose. It returns
+// a nil Credentials (and no error) if PROXMOX_USER is not set, whether or not
+// any of the other PROXMOX_* variables are, so callers can tell "not
+// configured this way" apart from a configuration error.
+func loadCredentialsFromEnv() (*Credentials, error) {
+	if _, err := os.Stat(dotEnvFilename); err == nil {
+		if err := godotenv.Load(dotEnvFilename); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", dotEnvFilename, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat %s: %w", dotEnvFilename, err)
+	}
+
+	username, ok := os.LookupEnv(envVarUsername)
+	if !ok {
+		return nil, nil
+	}
+
+	return &Credentials{
+		URL:       os.Getenv(envVarURL),
+		Username:  username,
+		Password:  os.Getenv(envVarPassword),
+		TokenID:   os.Getenv(envVarTokenID),
+		Realm:     os.Getenv(envVarRealm),
+		OtpSecret: os.Getenv(envVarOtpSecret),
+	}, nil
+}
+
+// Redacted returns a copy of the credentials with every secret field
+// replaced by a fixed placeholder, safe to pass to debug log output.
+func (c Credentials) Redacted() Credentials {
+	if c.Password != "" {
+		c.Password = "<redacted>"
+	}
+	if c.TokenID != "" {
+		c.TokenID = "<redacted>"
+	}
+	if c.OtpSecret != "" {
+		c.OtpSecret = "<redacted>"
+	}
+
+	return c
+}
@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+// adoptInstances queries the pool for pre-existing qemu VMs whose tags match
+// Settings.AdoptTagFilter and returns their instance IDs. startSessionTicketRefresher
+// calls this on startup and appends the result to ig.managedInstances, so
+// these VMs are registered as already-managed instead of being treated as
+// orphans to delete. This lets a restarted plugin (or a second instance
+// taking over in an HA failover) rediscover the runner VMs a previous
+// process created.
+//
+// Because the result is derived purely from tags and pool membership rather
+// than any local bookkeeping, calling this again (e.g. after another
+// restart) always re-derives the same set, making it idempotent.
+func (ig *InstanceGroup) adoptInstances(ctx context.Context) ([]string, error) {
+	if ig.Settings.AdoptTagFilter == "" {
+		return nil, nil
+	}
+
+	pool, err := ig.getProxmoxPool(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pool members for adoption: %w", err)
+	}
+
+	var adopted []string
+	for _, member := range pool.Members {
+		if member.Type != "qemu" {
+			continue
+		}
+
+		vm, err := ig.getProxmoxVMOnNode(ctx, int(member.VMID), member.Node)
+		if err != nil {
+			ig.log.Error("failed to inspect pool member during adoption", "vmid", member.VMID, "node", member.Node, slog.Any("err", err))
+			continue
+		}
+
+		// node.VirtualMachine only populates the status fields; the tags
+		// live in VirtualMachineConfig, which requires this separate call.
+		if err := vm.Config(ctx); err != nil {
+			ig.log.Error("failed to get vm config during adoption", "vmid", member.VMID, "node", member.Node, slog.Any("err", err))
+			continue
+		}
+
+		if !matchesAdoptTagFilter(ig.Settings.AdoptTagFilter, vm) {
+			continue
+		}
+
+		adopted = append(adopted, fmt.Sprintf("%d", vm.VMID))
+		ig.log.Info("adopted pre-existing vm as runner instance", "vmid", vm.VMID, "node", member.Node)
+	}
+
+	return adopted, nil
+}
+
+// matchesAdoptTagFilter reports whether vm carries one of the comma-separated
+// tags in filter. Proxmox stores a VM's tags as a single semicolon-separated
+// string on its config.
+func matchesAdoptTagFilter(filter string, vm *proxmox.VirtualMachine) bool {
+	present := strings.Split(vm.VirtualMachineConfig.Tags, ";")
+
+	for _, wanted := range strings.Split(filter, ",") {
+		wanted = strings.TrimSpace(wanted)
+		if wanted == "" {
+			continue
+		}
+
+		for _, tag := range present {
+			if strings.TrimSpace(tag) == wanted {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Increase clones Settings.TemplateVMID delta times, starts each clone with
+// Settings.StartOptions applied, and returns how many it actually got
+// running. A clone that fails, or fails to start, is left behind rather than
+// retried so one bad clone can't stall the rest of the batch; the caller
+// sees the shortfall in the returned count.
+func (ig *InstanceGroup) Increase(ctx context.Context, delta int) (int, error) {
+	template, err := ig.getProxmoxVM(ctx, ig.Settings.TemplateVMID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get template vm='%d': %w", ig.Settings.TemplateVMID, err)
+	}
+
+	started := 0
+	for i := 0; i < delta; i++ {
+		vm, err := ig.cloneProxmoxVM(ctx, template)
+		if err != nil {
+			ig.log.Error("failed to clone template vm during increase", "template_vmid", ig.Settings.TemplateVMID, slog.Any("err", err))
+			continue
+		}
+
+		if err := ig.startProxmoxVM(ctx, vm); err != nil {
+			ig.log.Error("failed to start cloned vm during increase", "vmid", vm.VMID, slog.Any("err", err))
+			continue
+		}
+
+		started++
+	}
+
+	return started, nil
+}
@@ -0,0 +1,19 @@
+package plugin
+
+import (
+	"log/slog"
+	"os"
+)
+
+// newInstanceLogger builds the *slog.Logger used as ig.log. When
+// Settings.LogHandler is set (for example to route logs through a JSON
+// handler so a downstream log pipeline gets structured fields instead of
+// string-concatenated messages), it's used as-is; otherwise a text handler
+// on os.Stderr matches the plugin's previous default output.
+func newInstanceLogger(handler slog.Handler) *slog.Logger {
+	if handler == nil {
+		handler = slog.NewTextHandler(os.Stderr, nil)
+	}
+
+	return slog.New(handler)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -16,8 +17,9 @@ import (
 var ErrNotFound = errors.New("not found")
 
 type Credentials struct {
+	URL       string `json:"url,omitempty"` // Only used when Settings.URL is unset, e.g. when loading from the environment
 	Username  string `json:"username"`
-	Password  string `json:"password"`	          // Password or token secret
+	Password  string `json:"password"` // Password or token secret
 	TokenID   string `json:"token,omitifempty"`
 	OtpSecret string `json:"otpsecret,omitempty"` // Secret token for OTP generation
 	Path      string `json:"path,omitempty"`
@@ -54,32 +56,66 @@ func (ig *InstanceGroup) getProxmoxVM(ctx context.Context, vmid int) (*proxmox.V
 	return nil, ErrNotFound
 }
 
+// getProxmoxVMOnNode looks up vmid on nodeName without starting or cloning
+// it. See Increase and cloneProxmoxVM for the call sites that do either once
+// the VM is found.
 func (ig *InstanceGroup) getProxmoxVMOnNode(ctx context.Context, vmid int, nodeName string) (*proxmox.VirtualMachine, error) {
+	log := ig.log.With("pool", ig.Settings.Pool, "node", nodeName, "vmid", vmid)
+
 	node, err := ig.proxmox.Node(ctx, nodeName)
 	if err != nil {
+		log.Error("failed to get node", slog.Any("err", err))
 		return nil, fmt.Errorf("failed to get node='%s': %w", nodeName, err)
 	}
 
 	vm, err := node.VirtualMachine(ctx, vmid)
 	if err != nil {
+		log.Error("failed to get vm", slog.Any("err", err))
 		return nil, fmt.Errorf("failed to get vm='%d' on node='%s': %w", vmid, nodeName, err)
 	}
 
 	return vm, nil
 }
 
-func (ig *InstanceGroup) getProxmoxClient() (*proxmox.Client, error) {
-	url, err := url.Parse(ig.Settings.URL)
+// cloneProxmoxVM clones template onto the same node, waits for the clone
+// task to finish, and returns a handle to the new VM. Increase calls this
+// once per instance it needs to bring up.
+func (ig *InstanceGroup) cloneProxmoxVM(ctx context.Context, template *proxmox.VirtualMachine) (*proxmox.VirtualMachine, error) {
+	newid, task, err := template.Clone(ctx, &proxmox.VirtualMachineCloneOptions{
+		Pool: ig.Settings.Pool,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL='%s': %w", ig.Settings.URL, err)
+		return nil, fmt.Errorf("failed to clone template vm='%d': %w", template.VMID, err)
 	}
 
+	if err := task.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("failed waiting for clone of vm='%d' to finish: %w", template.VMID, err)
+	}
+
+	return ig.getProxmoxVMOnNode(ctx, newid, template.Node)
+}
+
+func (ig *InstanceGroup) getProxmoxClient() (*proxmox.Client, error) {
+	// Apply Settings.LogHandler, if configured, before anything below logs
+	// through ig.log.
+	ig.log = newInstanceLogger(ig.Settings.LogHandler)
+
 	credentials, err := ig.getProxmoxCredentials()
 	if err != nil {
 		return nil, err
 	}
 
-	httpClient := http.Client{
+	rawURL := ig.Settings.URL
+	if rawURL == "" {
+		rawURL = credentials.URL
+	}
+
+	url, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL='%s': %w", rawURL, err)
+	}
+
+	httpClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{
 				//nolint:gosec
@@ -88,44 +124,95 @@ func (ig *InstanceGroup) getProxmoxClient() (*proxmox.Client, error) {
 		},
 	}
 
-	if credentials.TokenID == "" {
-		// No token, normal login with username and password
-		proxmoxCredentials := proxmox.Credentials{}
-		proxmoxCredentials.Username = credentials.Username
-		proxmoxCredentials.Password = credentials.Password
-		proxmoxCredentials.Path     = credentials.Path
-		proxmoxCredentials.Privs    = credentials.Privs
-		proxmoxCredentials.Realm    = credentials.Realm
-
-		return proxmox.NewClient(
-			url.JoinPath("/api2/json").String(),
-			proxmox.WithCredentials(proxmoxCredentials),
-			proxmox.WithHTTPClient(&httpClient),
-		), nil
-	} else {
-		// Token available, log in with API token
+	if credentials.TokenID != "" {
+		// Token available, log in with API token. API tokens are static,
+		// so there's no ticket to keep fresh and no CredentialSource is
+		// needed.
 		apiToken := fmt.Sprintf("%s@%s!%s", credentials.Username, credentials.Realm, credentials.TokenID)
+		ig.credentialSource = apiTokenCredentialSource{}
 
 		return proxmox.NewClient(
 			url.JoinPath("/api2/json").String(),
 			proxmox.WithAPIToken(apiToken, credentials.Password),
-			proxmox.WithHTTPClient(&httpClient),
+			proxmox.WithHTTPClient(httpClient),
 		), nil
 	}
 
-	
+	// No token, normal login with username and password (plus TOTP, if
+	// the realm requires it). The client queries a CredentialSource for
+	// its ticket on every request instead of a client built once with a
+	// static ticket, so startSessionTicketRefresher can keep long-lived
+	// InstanceGroups authenticated past Proxmox's session lifetime.
+	proxmoxCredentials := proxmox.Credentials{}
+	proxmoxCredentials.Username = credentials.Username
+	proxmoxCredentials.Password = credentials.Password
+	proxmoxCredentials.Path = credentials.Path
+	proxmoxCredentials.Privs = credentials.Privs
+	proxmoxCredentials.Realm = credentials.Realm
+
+	client := proxmox.NewClient(
+		url.JoinPath("/api2/json").String(),
+		proxmox.WithHTTPClient(httpClient),
+	)
+
+	credSrc := newTicketCredentialSource(client, proxmoxCredentials, credentials.OtpSecret)
+	if err := credSrc.Refresh(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to log in to proxmox: %w", err)
+	}
+
+	httpClient.Transport = &ticketTransport{base: httpClient.Transport, credSrc: credSrc}
+	ig.credentialSource = credSrc
+
+	return client, nil
 }
 
+// getProxmoxCredentials loads the Proxmox login from, in order of
+// precedence: an explicitly configured CredentialsFilePath, PROXMOX_*
+// environment variables, and a .env file in the working directory (used to
+// populate any of those variables that aren't already set by the real
+// environment). CredentialsFilePath always wins over the environment; it
+// only fails with a conflict error if PROXMOX_* variables are set in the
+// real environment too, since those were placed there deliberately. A .env
+// file picked up as a fallback never triggers that error, so a stray one
+// left in a container's working directory can't break startup for an
+// operator who configured credentials_file.
 func (ig *InstanceGroup) getProxmoxCredentials() (*Credentials, error) {
-	credentialsFile, err := os.Open(ig.Settings.CredentialsFilePath)
+	if ig.Settings.CredentialsFilePath != "" {
+		if explicitEnvCredentialsSet() {
+			return nil, fmt.Errorf("credentials_file='%s' is set but PROXMOX_* environment variables are also present; configure only one", ig.Settings.CredentialsFilePath)
+		}
+
+		credentials, err := loadCredentialsFromFile(ig.Settings.CredentialsFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		ig.log.Debug("loaded proxmox credentials", "source", "credentials_file", "credentials", credentials.Redacted())
+		return credentials, nil
+	}
+
+	credentials, err := loadCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if credentials == nil {
+		return nil, fmt.Errorf("no credentials configured: set credentials_file or the PROXMOX_* environment variables")
+	}
+
+	ig.log.Debug("loaded proxmox credentials", "source", "environment", "credentials", credentials.Redacted())
+	return credentials, nil
+}
+
+func loadCredentialsFromFile(path string) (*Credentials, error) {
+	credentialsFile, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open credentials file from path='%s': %w", ig.Settings.CredentialsFilePath, err)
+		return nil, fmt.Errorf("failed to open credentials file from path='%s': %w", path, err)
 	}
 	defer credentialsFile.Close()
 
 	credentials := Credentials{}
 	if err := json.NewDecoder(credentialsFile).Decode(&credentials); err != nil {
-		return nil, fmt.Errorf("failed to decode credentials file from path='%s': %w", ig.Settings.CredentialsFilePath, err)
+		return nil, fmt.Errorf("failed to decode credentials file from path='%s': %w", path, err)
 	}
 
 	return &credentials, nil
@@ -2,17 +2,33 @@ package plugin
 
 import (
 	"context"
+	"log/slog"
 	"time"
-
-	"github.com/luthermonson/go-proxmox"
 )
 
 const (
 	sessionTicketRefreshInterval = 1 * time.Hour
 	sessionTicketRefreshTimeout  = 5 * time.Second
+	adoptInstancesTimeout        = 30 * time.Second
 )
 
 func (ig *InstanceGroup) startSessionTicketRefresher() {
+	// Adopt any pre-existing tagged pool VMs before anything else runs, so
+	// they're registered as managed instances before orphan cleanup gets a
+	// chance to treat them as stray VMs to delete. Bounded the same way as
+	// the periodic refresh below, so a slow or unreachable Proxmox API at
+	// startup can't block the caller forever.
+	func() {
+		ctx, cancel := context.WithTimeout(context.Background(), adoptInstancesTimeout)
+		defer cancel()
+
+		if adopted, err := ig.adoptInstances(ctx); err != nil {
+			ig.log.Error("failed to adopt pre-existing pool vms", slog.Any("err", err))
+		} else if len(adopted) > 0 {
+			ig.managedInstances = append(ig.managedInstances, adopted...)
+		}
+	}()
+
 	ig.sessionTicketRefresherWaitGroup.Add(1)
 
 	go func() {
@@ -21,6 +37,12 @@ func (ig *InstanceGroup) startSessionTicketRefresher() {
 	}()
 }
 
+// refreshableCredentialSource is implemented by CredentialSources that hold
+// a ticket which goes stale over time. apiTokenCredentialSource doesn't
+// implement it, since API tokens never expire and need no refresh.
+type refreshableCredentialSource interface {
+	Refresh(ctx context.Context) error
+}
 
 func (ig *InstanceGroup) runSessionTicketRefresher() {
 	for {
@@ -32,21 +54,14 @@ func (ig *InstanceGroup) runSessionTicketRefresher() {
 				ctx, cancel := context.WithTimeout(context.Background(), sessionTicketRefreshTimeout)
 				defer cancel()
 
-				credentials, err := ig.getProxmoxCredentials()
-				if err != nil {
-					ig.log.Error("failed to refresh proxmox session, could not read credentials", "err", err)
+				refresher, ok := ig.credentialSource.(refreshableCredentialSource)
+				if !ok {
 					return
 				}
 
-				proxmoxCredentials := proxmox.Credentials{}
-				proxmoxCredentials.Username = credentials.Username
-				//proxmoxCredentials.Realm = credentials.Realm
-				//proxmoxCredentials.Password = ig.proxmox.session.Ticket
-
-				// Refresh Ticket using old Ticket
-				_, err = ig.proxmox.Ticket(ctx, *proxmoxCredentials)
-				if err != nil {
-					ig.log.Error("failed to refresh proxmox session", "err", err)
+				if err := refresher.Refresh(ctx); err != nil {
+					ig.log.Error("failed to refresh proxmox session", slog.Any("err", err))
+					return
 				}
 
 				ig.log.Info("refreshed proxmox session")
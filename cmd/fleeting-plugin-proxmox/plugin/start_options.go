@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+// VMStartOptions mirrors go-proxmox's VirtualMachineStartOption. Every field
+// is optional and left at its zero value by default, in which case it's
+// omitted from the start API call entirely so behavior is unchanged from
+// before these options existed.
+type VMStartOptions struct {
+	ForceCPU         string `json:"force_cpu,omitempty"`
+	Machine          string `json:"machine,omitempty"`
+	MigratedFrom     string `json:"migrated_from,omitempty"`
+	MigrationNetwork string `json:"migration_network,omitempty"`
+	MigrationType    string `json:"migration_type,omitempty"`
+	StateURI         string `json:"state_uri,omitempty"`
+	TargetStorage    string `json:"target_storage,omitempty"`
+}
+
+// asProxmoxOption converts the configured start options to the go-proxmox
+// request type, or returns nil if none are set, so the start call omits the
+// corresponding form fields entirely rather than sending empty strings.
+func (o VMStartOptions) asProxmoxOption() *proxmox.VirtualMachineStartOption {
+	if o == (VMStartOptions{}) {
+		return nil
+	}
+
+	return &proxmox.VirtualMachineStartOption{
+		ForceCPU:         o.ForceCPU,
+		Machine:          o.Machine,
+		MigratedFrom:     o.MigratedFrom,
+		MigrationNetwork: o.MigrationNetwork,
+		MigrationType:    o.MigrationType,
+		StateURI:         o.StateURI,
+		TargetStorage:    o.TargetStorage,
+	}
+}
+
+// startProxmoxVM starts vm using the operator-configured Settings.StartOptions
+// (if any) and waits for the resulting task to finish, so the increase path
+// only returns once the instance is actually booting.
+func (ig *InstanceGroup) startProxmoxVM(ctx context.Context, vm *proxmox.VirtualMachine) error {
+	task, err := vm.Start(ctx, ig.Settings.StartOptions.asProxmoxOption())
+	if err != nil {
+		return fmt.Errorf("failed to start vm='%d': %w", vm.VMID, err)
+	}
+
+	if err := task.Wait(ctx); err != nil {
+		return fmt.Errorf("failed waiting for vm='%d' to start: %w", vm.VMID, err)
+	}
+
+	return nil
+}